@@ -0,0 +1,144 @@
+package env
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// DotEnv creates a Provider that reads environment variables from one or more
+// files in the .env format. Files are read in the order given, and, like
+// MultiProvider, later files take precedence over earlier ones when they
+// define the same key. Compose DotEnv with OS through MultiProvider to let
+// real environment variables override values loaded from .env files:
+//
+//	dotenv, err := env.DotEnv(".env")
+//	provider := env.MultiProvider(dotenv, env.OS)
+func DotEnv(paths ...string) (Provider, error) {
+	vars := make(Map)
+
+	for _, path := range paths {
+		if err := parseDotEnvFile(path, vars); err != nil {
+			return nil, err
+		}
+	}
+
+	return vars, nil
+}
+
+// DotEnvReader creates a Provider that reads environment variables from r in
+// the .env format. It is useful for testing and for parsing .env contents
+// embedded into a binary.
+func DotEnvReader(r io.Reader) (Provider, error) {
+	vars := make(Map)
+	if err := parseDotEnv(r, vars); err != nil {
+		return nil, fmt.Errorf("env: failed to parse dotenv: %w", err)
+	}
+	return vars, nil
+}
+
+// parseDotEnvFile opens path and parses it in the .env format, adding the
+// parsed variables to vars.
+func parseDotEnvFile(path string, vars Map) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("env: failed to open %q: %w", path, err)
+	}
+	defer f.Close()
+
+	if err := parseDotEnv(f, vars); err != nil {
+		return fmt.Errorf("env: failed to parse %q: %w", path, err)
+	}
+
+	return nil
+}
+
+// parseDotEnv parses the .env format from r, adding the parsed variables to
+// vars. ${VAR} references inside a value are expanded against vars as each
+// line is read, so only keys already in vars are resolved: those defined on
+// earlier lines, and, for DotEnv, those defined by earlier files.
+func parseDotEnv(r io.Reader, vars Map) error {
+	scanner := bufio.NewScanner(r)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
+
+		idx := strings.IndexByte(line, '=')
+		if idx < 0 {
+			return fmt.Errorf("invalid line %q", line)
+		}
+
+		key := strings.TrimSpace(line[:idx])
+		if key == "" {
+			return fmt.Errorf("empty key in line %q", line)
+		}
+
+		value, expand, err := parseDotEnvValue(strings.TrimSpace(line[idx+1:]))
+		if err != nil {
+			return fmt.Errorf("invalid value for %q: %w", key, err)
+		}
+		if expand {
+			value = os.Expand(value, func(k string) string { return vars[k] })
+		}
+
+		vars[key] = value
+	}
+
+	return scanner.Err()
+}
+
+// parseDotEnvValue strips quoting and resolves escape sequences from a single
+// .env value. It returns whether the value is eligible for ${VAR} expansion:
+// single-quoted values are taken literally, matching common .env conventions.
+// Only full-line `#` comments are recognized; an unquoted value is used
+// as-is, `#` and all, so values like COLOR=#ff0000 round-trip correctly.
+func parseDotEnvValue(value string) (result string, expand bool, err error) {
+	switch {
+	case len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"':
+		result, err = unescapeDotEnv(value[1 : len(value)-1])
+		return result, true, err
+	case len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'':
+		return value[1 : len(value)-1], false, nil
+	default:
+		return value, true, nil
+	}
+}
+
+// unescapeDotEnv resolves the \n, \t, \\ and \" escape sequences recognized
+// inside a double-quoted .env value.
+func unescapeDotEnv(s string) (string, error) {
+	var b strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '\\' {
+			b.WriteByte(c)
+			continue
+		}
+		if i+1 >= len(s) {
+			return "", fmt.Errorf("trailing backslash")
+		}
+		i++
+		switch s[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case '\\':
+			b.WriteByte('\\')
+		case '"':
+			b.WriteByte('"')
+		default:
+			b.WriteByte('\\')
+			b.WriteByte(s[i])
+		}
+	}
+
+	return b.String(), nil
+}
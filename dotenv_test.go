@@ -0,0 +1,108 @@
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDotEnvReaderUnquotedHash(t *testing.T) {
+	p, err := DotEnvReader(strings.NewReader(`
+COLOR=#ff0000
+PASSWORD=p#ssw0rd
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v, _ := p.LookupEnv("COLOR"); v != "#ff0000" {
+		t.Errorf("COLOR = %q, want %q", v, "#ff0000")
+	}
+	if v, _ := p.LookupEnv("PASSWORD"); v != "p#ssw0rd" {
+		t.Errorf("PASSWORD = %q, want %q", v, "p#ssw0rd")
+	}
+}
+
+func TestDotEnvReaderParsing(t *testing.T) {
+	p, err := DotEnvReader(strings.NewReader(`
+# a full-line comment is ignored
+
+export EXPORTED=exported-value
+DOUBLE="line one\nline two\ttabbed\\slash\"quote"
+SINGLE='$LITERAL ${NOT_EXPANDED}'
+NAME=World
+GREETING="Hello, ${NAME}!"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]string{
+		"EXPORTED": "exported-value",
+		"DOUBLE":   "line one\nline two\ttabbed\\slash\"quote",
+		"SINGLE":   "$LITERAL ${NOT_EXPANDED}",
+		"GREETING": "Hello, World!",
+	}
+	for key, want := range cases {
+		if v, ok := p.LookupEnv(key); !ok || v != want {
+			t.Errorf("%s = %q, %v; want %q, true", key, v, ok, want)
+		}
+	}
+}
+
+func TestDotEnvReaderInvalidLine(t *testing.T) {
+	_, err := DotEnvReader(strings.NewReader("not-a-key-value-line"))
+	if err == nil {
+		t.Fatal("expected an error for a line without '='")
+	}
+}
+
+func TestDotEnvMultiFilePrecedence(t *testing.T) {
+	dir := t.TempDir()
+
+	base := filepath.Join(dir, "base.env")
+	override := filepath.Join(dir, "override.env")
+	if err := os.WriteFile(base, []byte("NAME=base\nONLY_BASE=base-value\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(override, []byte("NAME=override\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := DotEnv(base, override)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v, _ := p.LookupEnv("NAME"); v != "override" {
+		t.Errorf("NAME = %q, want %q (later file should win)", v, "override")
+	}
+	if v, _ := p.LookupEnv("ONLY_BASE"); v != "base-value" {
+		t.Errorf("ONLY_BASE = %q, want %q", v, "base-value")
+	}
+}
+
+func TestDotEnvWithOSOverride(t *testing.T) {
+	t.Setenv("DOTENV_OS_OVERRIDE", "from-os")
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".env")
+	if err := os.WriteFile(path, []byte("DOTENV_OS_OVERRIDE=from-file\nDOTENV_ONLY=from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	dotenv, err := DotEnv(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	provider := MultiProvider(dotenv, OS)
+
+	if v, _ := provider.LookupEnv("DOTENV_OS_OVERRIDE"); v != "from-os" {
+		t.Errorf("DOTENV_OS_OVERRIDE = %q, want %q (real env should override .env)", v, "from-os")
+	}
+	if v, _ := provider.LookupEnv("DOTENV_ONLY"); v != "from-file" {
+		t.Errorf("DOTENV_ONLY = %q, want %q", v, "from-file")
+	}
+}
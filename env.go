@@ -4,6 +4,7 @@ package env
 import (
 	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"reflect"
 	"strings"
@@ -23,8 +24,13 @@ var (
 	ErrUnsupportedType = errors.New("env: unsupported type")
 
 	// ErrInvalidTagOption is returned when the `env` tag contains an invalid
-	// option, e.g. `env:"VAR,foo"`.
+	// option, e.g. `env:"VAR,foo"`, or when mutually exclusive options are
+	// combined, e.g. `env:"VAR,required,default=1"`.
 	ErrInvalidTagOption = errors.New("env: invalid tag option")
+
+	// ErrReadFile is returned when the "file" tag option is used and the file
+	// named by the environment variable's value cannot be read.
+	ErrReadFile = errors.New("env: failed to read file")
 )
 
 // NotSetError is returned when environment variables are marked as required but
@@ -59,27 +65,56 @@ func (e *NotSetError) Error() string {
 //  time.Duration
 //  encoding.TextUnmarshaler
 //  slices of any type above (space is the default separator for values)
+//  maps with a key and a value of any type above, e.g. MYAPP_COLORS="red:1 green:2"
 // See the strconv package from the standard library for parsing rules.
 // Implementing the encoding.TextUnmarshaler interface is enough to use any
-// user-defined type. Default values can be specified using basic struct
+// user-defined type; for third-party types that don't implement it (e.g.
+// *url.URL, net.IP, *regexp.Regexp), register a ParserFunc with WithParser
+// instead. Default values can be specified using basic struct
 // initialization. They will be left untouched, if no corresponding environment
 // variables are found. Nested structs of any depth level are supported, but
 // only non-struct fields are considered as targets for parsing. If a field of
 // an unsupported type is found, the error will be ErrUnsupportedType.
 //
+// A nested struct field tagged with `envPrefix:"PREFIX_"` (or, equivalently,
+// `env:",prefix=PREFIX_"`) adds PREFIX_ to the name of every environment
+// variable found within that field's subtree, on top of any outer prefix.
+// The prefix only applies to that subtree; sibling structs are unaffected.
+//
 // The name of the environment variable can be followed by comma-separated
 // options in the form of `env:"VAR,option1,option2,..."`. The following
 // tag-level options are supported:
-//  required: mark the environment variable as required
-//  expand:   expand the value of the environment variable using os.Expand
+//  required:     mark the environment variable as required
+//  expand:       expand the value of the environment variable using os.Expand
+//  file:         treat the value as a path and read the field's value from that file
+//  default=VALUE: use VALUE when the environment variable is not set
+// default=VALUE may be quoted (default="a,b,c") to allow arbitrary characters,
+// including commas, in VALUE. A default can also be specified with the
+// separate `envDefault:"VALUE"` struct tag, which is equivalent to
+// default=VALUE but does not need quoting to contain commas. required and
+// default are mutually exclusive; combining them returns ErrInvalidTagOption.
 // If environment variables are marked as required but not set, an error of type
 // NotSetError will be returned. If the tag contains an invalid option, the
 // error will be ErrInvalidTagOption.
 //
+// A field can also carry an `envDoc:"..."` struct tag with a short,
+// human-readable description. Load ignores it; it is picked up by Usage and
+// UsageTemplate to generate self-documenting help text for the struct.
+//
+// The file option reads the file at the path named by the environment
+// variable (using WithFS, or os.DirFS("/") by default) and uses its trimmed
+// contents as the field's value; this matches the convention used by
+// Kubernetes and Docker secret mounts. It composes with required (a missing
+// variable or a missing file both error) and with expand (the path itself is
+// expanded first). A read failure returns an error wrapping ErrReadFile.
+//
 // In addition to the tag-level options, Load also supports the following
 // function-level options:
 //  WithPrefix:         set prefix for each environment variable
 //  WithSliceSeparator: set custom separator to parse slice values
+//  WithMapSeparator:   set custom separator between a map entry's key and value
+//  WithFS:             set the fs.FS used to read files for the "file" tag option
+//  WithParser:         register a custom ParserFunc for a type not otherwise supported
 // See their documentation for details.
 func Load(dst interface{}, opts ...Option) error {
 	return newLoader(OS, opts...).loadVars(dst)
@@ -94,8 +129,10 @@ func LoadFrom(p Provider, dst interface{}, opts ...Option) error {
 // Option allows to customize the behaviour of Load/LoadFrom functions.
 type Option func(*loader)
 
-// WithPrefix configures Load/LoadFrom to automatically add the provided prefix
-// to each environment variable. By default, no prefix is configured.
+// WithPrefix configures Load/LoadFrom to automatically add the provided
+// prefix to each environment variable. It acts as the outermost prefix: any
+// envPrefix found on a nested struct is appended to it for that struct's
+// subtree only. By default, no prefix is configured.
 func WithPrefix(prefix string) Option {
 	return func(l *loader) { l.prefix = prefix }
 }
@@ -106,11 +143,52 @@ func WithSliceSeparator(sep string) Option {
 	return func(l *loader) { l.sliceSep = sep }
 }
 
+// WithMapSeparator configures Load/LoadFrom to use the provided separator
+// between a key and a value when parsing map entries (entries themselves are
+// split using the slice separator). The default one is ":".
+func WithMapSeparator(sep string) Option {
+	return func(l *loader) { l.mapSep = sep }
+}
+
+// WithFS configures Load/LoadFrom to read files named by variables using the
+// "file" tag option from fsys instead of the default os.DirFS("/"). This is
+// mainly useful in tests and for chrooted deployments.
+func WithFS(fsys fs.FS) Option {
+	return func(l *loader) { l.fsys = fsys }
+}
+
+// ParserFunc parses a raw environment variable value into an arbitrary Go
+// value, for types that Load does not support out of the box and that
+// cannot implement encoding.TextUnmarshaler themselves (e.g. *url.URL,
+// net.IP, *regexp.Regexp).
+type ParserFunc func(value string) (interface{}, error)
+
+// WithParser registers fn as the parser used for fields of type t (or of
+// type *t, if t is not itself a pointer type). It takes precedence over the
+// built-in scalar dispatch and over encoding.TextUnmarshaler, and also
+// applies to slice elements, so []t and []*t work the same way. This holds
+// even if t's own Kind is Struct, Map or Slice: a struct-kind t is treated as
+// a regular field instead of being recursed into as a nested config struct,
+// and a map/slice-kind t is parsed as a whole instead of being split with
+// the slice/map separators. Calling WithParser with a t already registered
+// replaces its parser.
+func WithParser(t reflect.Type, fn ParserFunc) Option {
+	return func(l *loader) {
+		if l.parsers == nil {
+			l.parsers = make(map[reflect.Type]ParserFunc)
+		}
+		l.parsers[t] = fn
+	}
+}
+
 // loader is an environment variables loader.
 type loader struct {
 	provider Provider
 	prefix   string
 	sliceSep string
+	mapSep   string
+	fsys     fs.FS
+	parsers  map[reflect.Type]ParserFunc
 }
 
 // newLoader creates a new loader with the specified Provider and applies the
@@ -120,6 +198,8 @@ func newLoader(p Provider, opts ...Option) *loader {
 		provider: p,
 		prefix:   "",
 		sliceSep: " ",
+		mapSep:   ":",
+		fsys:     os.DirFS("/"),
 	}
 	for _, opt := range opts {
 		opt(&l)
@@ -134,7 +214,7 @@ func (l *loader) loadVars(dst interface{}) error {
 		return ErrInvalidArgument
 	}
 
-	vars, err := l.parseVars(rv.Elem())
+	vars, err := l.parseVars(rv.Elem(), l.prefix)
 	if err != nil {
 		return err
 	}
@@ -148,15 +228,37 @@ func (l *loader) loadVars(dst interface{}) error {
 		if !ok {
 			if v.required {
 				notset = append(notset, v.name)
+				continue
+			}
+			if !v.hasDefault {
+				continue
+			}
+			value = v.defaultValue
+			if v.expand {
+				value = l.expandValue(value)
 			}
-			continue
 		}
 
+		if v.file {
+			content, err := fs.ReadFile(l.fsys, strings.TrimPrefix(value, "/"))
+			if err != nil {
+				return fmt.Errorf("%w: %s: %w", ErrReadFile, v.name, err)
+			}
+			value = strings.TrimSpace(string(content))
+		}
+
+		_, hasParser := l.lookupParser(v.field.Type())
+
 		var err error
-		if kindOf(v.field, reflect.Slice) && !implements(v.field, unmarshalerIface) {
-			err = setSlice(v.field, strings.Split(value, l.sliceSep))
-		} else {
-			err = setValue(v.field, value)
+		switch {
+		case hasParser:
+			err = l.setValue(v.field, value)
+		case kindOf(v.field, reflect.Map) && !implements(v.field, unmarshalerIface):
+			err = setMap(v.field, strings.Split(value, l.sliceSep), l.mapSep, l.setValue)
+		case kindOf(v.field, reflect.Slice) && !implements(v.field, unmarshalerIface):
+			err = setSlice(v.field, strings.Split(value, l.sliceSep), l.setValue)
+		default:
+			err = l.setValue(v.field, value)
 		}
 		if err != nil {
 			return err
@@ -171,8 +273,10 @@ func (l *loader) loadVars(dst interface{}) error {
 }
 
 // parseVars parses environment variables from the fields of the provided
-// struct.
-func (l *loader) parseVars(v reflect.Value) ([]variable, error) {
+// struct. prefix is prepended to every variable name found in v, including
+// those found in nested structs further down the tree, unless a nested
+// struct overrides it for its own subtree via envPrefix.
+func (l *loader) parseVars(v reflect.Value, prefix string) ([]variable, error) {
 	var vars []variable
 
 	for i := 0; i < v.NumField(); i++ {
@@ -182,9 +286,20 @@ func (l *loader) parseVars(v reflect.Value) ([]variable, error) {
 			continue
 		}
 
-		// special case: a nested struct, parse its fields recursively.
-		if kindOf(field, reflect.Struct) && !implements(field, unmarshalerIface) {
-			nested, err := l.parseVars(field)
+		sf := v.Type().Field(i)
+
+		// special case: a nested struct, parse its fields recursively. Its
+		// own envPrefix, if any, only applies within that subtree. A struct
+		// type with a custom parser registered via WithParser is treated as
+		// a regular scalar field instead, so third-party struct types (e.g.
+		// url.URL) can be parsed as a whole.
+		_, hasParser := l.lookupParser(field.Type())
+		if kindOf(field, reflect.Struct) && !implements(field, unmarshalerIface) && !hasParser {
+			childPrefix, err := nestedPrefix(sf)
+			if err != nil {
+				return nil, err
+			}
+			nested, err := l.parseVars(field, prefix+childPrefix)
 			if err != nil {
 				return nil, err
 			}
@@ -192,42 +307,84 @@ func (l *loader) parseVars(v reflect.Value) ([]variable, error) {
 			continue
 		}
 
-		sf := v.Type().Field(i)
 		value, ok := sf.Tag.Lookup("env")
 		if !ok {
 			// skip fields without the `env` tag.
 			continue
 		}
 
-		parts := strings.Split(value, ",")
+		parts := splitTagOptions(value)
 		name, options := parts[0], parts[1:]
 		if name == "" {
 			return nil, ErrEmptyTagName
 		}
 
-		var required, expand bool
+		var required, expand, hasDefault, file bool
+		var defaultValue string
 		for _, option := range options {
-			switch option {
-			case "required":
+			switch {
+			case option == "required":
 				required = true
-			case "expand":
+			case option == "expand":
 				expand = true
+			case option == "file":
+				file = true
+			case strings.HasPrefix(option, "default="):
+				hasDefault = true
+				defaultValue = unquoteTagValue(strings.TrimPrefix(option, "default="))
 			default:
 				return nil, fmt.Errorf("%w %q", ErrInvalidTagOption, option)
 			}
 		}
 
+		if def, ok := sf.Tag.Lookup("envDefault"); ok && !hasDefault {
+			hasDefault = true
+			defaultValue = def
+		}
+
+		if required && hasDefault {
+			return nil, fmt.Errorf("%w: required and default are mutually exclusive", ErrInvalidTagOption)
+		}
+
 		vars = append(vars, variable{
-			name:     l.prefix + name,
-			required: required,
-			expand:   expand,
-			field:    field,
+			name:         prefix + name,
+			required:     required,
+			expand:       expand,
+			hasDefault:   hasDefault,
+			defaultValue: defaultValue,
+			file:         file,
+			doc:          sf.Tag.Get("envDoc"),
+			field:        field,
 		})
 	}
 
 	return vars, nil
 }
 
+// nestedPrefix returns the prefix to apply within a nested struct field's own
+// subtree, taken from its `envPrefix:"..."` tag or, equivalently, from a
+// `prefix=...` option in its `env` tag (e.g. `env:",prefix=DB_"`). It returns
+// an empty string if neither is present.
+func nestedPrefix(sf reflect.StructField) (string, error) {
+	if p, ok := sf.Tag.Lookup("envPrefix"); ok {
+		return p, nil
+	}
+
+	value, ok := sf.Tag.Lookup("env")
+	if !ok {
+		return "", nil
+	}
+
+	parts := splitTagOptions(value)
+	for _, option := range parts[1:] {
+		if p, ok := strings.CutPrefix(option, "prefix="); ok {
+			return unquoteTagValue(p), nil
+		}
+	}
+
+	return "", nil
+}
+
 // lookupEnv retrieves the value of the environment variable named by the key
 // using the internal Provider. It replaces $VAR or ${VAR} in the result using
 // os.Expand if expand is true.
@@ -241,19 +398,122 @@ func (l *loader) lookupEnv(key string, expand bool) (string, bool) {
 		return value, true
 	}
 
+	return l.expandValue(value), true
+}
+
+// setValue assigns value to field, preferring a custom parser registered via
+// WithParser for field's type (or pointer-to-field's type) over the built-in
+// dispatch in setValue.
+func (l *loader) setValue(field reflect.Value, value string) error {
+	if fn, ok := l.lookupParser(field.Type()); ok {
+		return setParsed(field, fn, value)
+	}
+	return setValue(field, value)
+}
+
+// lookupParser returns the custom parser registered for t, matching against
+// both t and its pointer-to/pointee counterpart: a parser registered for a
+// pointer type applies to the non-pointer field type and vice versa.
+func (l *loader) lookupParser(t reflect.Type) (ParserFunc, bool) {
+	if fn, ok := l.parsers[t]; ok {
+		return fn, true
+	}
+	if t.Kind() == reflect.Ptr {
+		if fn, ok := l.parsers[t.Elem()]; ok {
+			return fn, true
+		}
+		return nil, false
+	}
+	if fn, ok := l.parsers[reflect.PointerTo(t)]; ok {
+		return fn, true
+	}
+	return nil, false
+}
+
+// setParsed calls fn and assigns its result to field, dereferencing or
+// taking the address of the parsed value as needed to match field's type.
+func setParsed(field reflect.Value, fn ParserFunc, value string) error {
+	parsed, err := fn(value)
+	if err != nil {
+		return err
+	}
+
+	pv := reflect.ValueOf(parsed)
+	if !pv.IsValid() {
+		return fmt.Errorf("%w: parser for %s returned nil", ErrUnsupportedType, field.Type())
+	}
+
+	switch {
+	case pv.Type().AssignableTo(field.Type()):
+		field.Set(pv)
+	case pv.Kind() == reflect.Ptr && !pv.IsNil() && pv.Elem().Type().AssignableTo(field.Type()):
+		field.Set(pv.Elem())
+	case field.Kind() == reflect.Ptr && pv.Type().AssignableTo(field.Type().Elem()):
+		ptr := reflect.New(field.Type().Elem())
+		ptr.Elem().Set(pv)
+		field.Set(ptr)
+	default:
+		return fmt.Errorf("%w: parser for %s returned %s", ErrUnsupportedType, field.Type(), pv.Type())
+	}
+
+	return nil
+}
+
+// expandValue replaces $VAR or ${VAR} in value using os.Expand, looking up
+// replacements through the internal Provider.
+func (l *loader) expandValue(value string) string {
 	mapping := func(key string) string {
 		v, _ := l.provider.LookupEnv(key)
 		return v
 	}
 
-	return os.Expand(value, mapping), true
+	return os.Expand(value, mapping)
+}
+
+// splitTagOptions splits a raw `env` tag value on commas, the same way
+// strings.Split does, except that commas inside a double-quoted segment
+// (e.g. default="a,b,c") are not treated as separators.
+func splitTagOptions(tag string) []string {
+	var opts []string
+	var b strings.Builder
+	inQuotes := false
+
+	for i := 0; i < len(tag); i++ {
+		c := tag[i]
+		switch {
+		case c == '"':
+			inQuotes = !inQuotes
+			b.WriteByte(c)
+		case c == ',' && !inQuotes:
+			opts = append(opts, b.String())
+			b.Reset()
+		default:
+			b.WriteByte(c)
+		}
+	}
+	opts = append(opts, b.String())
+
+	return opts
+}
+
+// unquoteTagValue strips a single pair of surrounding double quotes from a
+// tag option value, if present, so that default="a,b,c" yields a,b,c.
+func unquoteTagValue(value string) string {
+	if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+		return value[1 : len(value)-1]
+	}
+	return value
 }
 
 // variable contains information about an environment variable parsed from a
 // struct field.
 type variable struct {
-	name     string
-	required bool
-	expand   bool
-	field    reflect.Value // the original struct field.
+	name         string
+	required     bool
+	expand       bool
+	hasDefault   bool
+	defaultValue string
+	file         bool
+	doc          string
+	field        reflect.Value // the original struct field.
 }
\ No newline at end of file
@@ -0,0 +1,398 @@
+package env
+
+import (
+	"errors"
+	"io/fs"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadDefault(t *testing.T) {
+	type Config struct {
+		Quoted   string `env:"QUOTED,default=\"a,b,c\""`
+		Plain    string `env:"PLAIN,default=fallback"`
+		FromEnv  string `env:"FROM_ENV,default=fallback"`
+		ViaTag   string `env:"VIA_TAG" envDefault:"tag-default"`
+		Combined string `env:"COMBINED,default=option-wins" envDefault:"tag-loses"`
+	}
+
+	var c Config
+	err := LoadFrom(Map{"FROM_ENV": "set"}, &c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c.Quoted != "a,b,c" {
+		t.Errorf("Quoted = %q, want %q", c.Quoted, "a,b,c")
+	}
+	if c.Plain != "fallback" {
+		t.Errorf("Plain = %q, want %q", c.Plain, "fallback")
+	}
+	if c.FromEnv != "set" {
+		t.Errorf("FromEnv = %q, want %q (env should win over default)", c.FromEnv, "set")
+	}
+	if c.ViaTag != "tag-default" {
+		t.Errorf("ViaTag = %q, want %q", c.ViaTag, "tag-default")
+	}
+	if c.Combined != "option-wins" {
+		t.Errorf("Combined = %q, want %q (default= option should win over envDefault)", c.Combined, "option-wins")
+	}
+}
+
+func TestLoadDefaultExpand(t *testing.T) {
+	type Config struct {
+		Greeting string `env:"GREETING,expand,default=Hello ${NAME}"`
+	}
+
+	var c Config
+	if err := LoadFrom(Map{"NAME": "World"}, &c); err != nil {
+		t.Fatal(err)
+	}
+
+	if c.Greeting != "Hello World" {
+		t.Errorf("Greeting = %q, want %q", c.Greeting, "Hello World")
+	}
+}
+
+func TestLoadRequiredAndDefaultConflict(t *testing.T) {
+	type Config struct {
+		X string `env:"X,required,default=fallback"`
+	}
+
+	var c Config
+	err := LoadFrom(Map{}, &c)
+	if !errors.Is(err, ErrInvalidTagOption) {
+		t.Fatalf("err = %v, want ErrInvalidTagOption", err)
+	}
+}
+
+func TestLoadRequiredAndEnvDefaultConflict(t *testing.T) {
+	type Config struct {
+		X string `env:"X,required" envDefault:"fallback"`
+	}
+
+	var c Config
+	err := LoadFrom(Map{}, &c)
+	if !errors.Is(err, ErrInvalidTagOption) {
+		t.Fatalf("err = %v, want ErrInvalidTagOption", err)
+	}
+}
+
+func TestLoadNestedPrefix(t *testing.T) {
+	type Inner struct {
+		Password string `env:"PASSWORD"`
+	}
+	type DB struct {
+		Host  string `env:"HOST"`
+		Inner Inner  `envPrefix:"INNER_"`
+	}
+	type Redis struct {
+		Host string `env:"HOST"`
+	}
+	type Config struct {
+		DB    DB    `envPrefix:"DB_"`
+		Redis Redis `env:",prefix=REDIS_"`
+	}
+
+	p := Map{
+		"APP_DB_HOST":           "db-host",
+		"APP_DB_INNER_PASSWORD": "secret",
+		"APP_REDIS_HOST":        "redis-host",
+	}
+
+	var c Config
+	if err := LoadFrom(p, &c, WithPrefix("APP_")); err != nil {
+		t.Fatal(err)
+	}
+
+	if c.DB.Host != "db-host" {
+		t.Errorf("DB.Host = %q, want %q", c.DB.Host, "db-host")
+	}
+	if c.DB.Inner.Password != "secret" {
+		t.Errorf("DB.Inner.Password = %q, want %q (prefixes should stack)", c.DB.Inner.Password, "secret")
+	}
+	if c.Redis.Host != "redis-host" {
+		t.Errorf("Redis.Host = %q, want %q", c.Redis.Host, "redis-host")
+	}
+}
+
+func TestLoadSiblingPrefixesDontLeak(t *testing.T) {
+	type Sub struct {
+		Value string `env:"VALUE"`
+	}
+	type Config struct {
+		A Sub `envPrefix:"A_"`
+		B Sub
+	}
+
+	p := Map{
+		"A_VALUE": "from-a",
+		"VALUE":   "from-b",
+	}
+
+	var c Config
+	if err := LoadFrom(p, &c); err != nil {
+		t.Fatal(err)
+	}
+
+	if c.A.Value != "from-a" {
+		t.Errorf("A.Value = %q, want %q", c.A.Value, "from-a")
+	}
+	if c.B.Value != "from-b" {
+		t.Errorf("B.Value = %q, want %q (A's prefix must not leak into B)", c.B.Value, "from-b")
+	}
+}
+
+func TestLoadMap(t *testing.T) {
+	type Config struct {
+		Colors map[string]int `env:"COLORS"`
+	}
+
+	var c Config
+	// "red" repeated: the last occurrence should win, like the environment itself.
+	err := LoadFrom(Map{"COLORS": "red:1 green:2 red:3"}, &c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]int{"red": 3, "green": 2}
+	if len(c.Colors) != len(want) {
+		t.Fatalf("Colors = %v, want %v", c.Colors, want)
+	}
+	for k, v := range want {
+		if c.Colors[k] != v {
+			t.Errorf("Colors[%q] = %d, want %d", k, c.Colors[k], v)
+		}
+	}
+}
+
+func TestLoadMapCustomSeparator(t *testing.T) {
+	type Config struct {
+		Colors map[string]int `env:"COLORS"`
+	}
+
+	var c Config
+	err := LoadFrom(Map{"COLORS": "red=1,green=2"}, &c,
+		WithSliceSeparator(","),
+		WithMapSeparator("="),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c.Colors["red"] != 1 || c.Colors["green"] != 2 {
+		t.Errorf("Colors = %v, want map[red:1 green:2]", c.Colors)
+	}
+}
+
+func TestLoadMapInvalidEntry(t *testing.T) {
+	type Config struct {
+		Colors map[string]int `env:"COLORS"`
+	}
+
+	var c Config
+	err := LoadFrom(Map{"COLORS": "red"}, &c)
+	if err == nil {
+		t.Fatal("expected an error for an entry without a key/value separator")
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	type Config struct {
+		Password string `env:"PASSWORD,file"`
+	}
+
+	fsys := fstest.MapFS{
+		"secrets/password": {Data: []byte("hunter2\n")},
+	}
+
+	var c Config
+	err := LoadFrom(Map{"PASSWORD": "/secrets/password"}, &c, WithFS(fsys))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c.Password != "hunter2" {
+		t.Errorf("Password = %q, want %q (trimmed file contents)", c.Password, "hunter2")
+	}
+}
+
+func TestLoadFileExpandsPathFirst(t *testing.T) {
+	type Config struct {
+		Password string `env:"PASSWORD,file,expand"`
+	}
+
+	fsys := fstest.MapFS{
+		"secrets/password": {Data: []byte("hunter2")},
+	}
+
+	var c Config
+	p := Map{
+		"SECRET_DIR": "secrets",
+		"PASSWORD":   "/${SECRET_DIR}/password",
+	}
+	if err := LoadFrom(p, &c, WithFS(fsys)); err != nil {
+		t.Fatal(err)
+	}
+
+	if c.Password != "hunter2" {
+		t.Errorf("Password = %q, want %q", c.Password, "hunter2")
+	}
+}
+
+func TestLoadFileMissingVariableRequired(t *testing.T) {
+	type Config struct {
+		Password string `env:"PASSWORD,file,required"`
+	}
+
+	var c Config
+	err := LoadFrom(Map{}, &c, WithFS(fstest.MapFS{}))
+
+	var notset *NotSetError
+	if !errors.As(err, &notset) {
+		t.Fatalf("err = %v, want *NotSetError", err)
+	}
+}
+
+func TestLoadFileMissingFile(t *testing.T) {
+	type Config struct {
+		Password string `env:"PASSWORD,file,required"`
+	}
+
+	var c Config
+	err := LoadFrom(Map{"PASSWORD": "/does/not/exist"}, &c, WithFS(fstest.MapFS{}))
+
+	if !errors.Is(err, ErrReadFile) {
+		t.Fatalf("err = %v, want ErrReadFile", err)
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("err = %v, want it to also wrap fs.ErrNotExist", err)
+	}
+}
+
+// upperString implements encoding.TextUnmarshaler, to verify that a
+// registered ParserFunc takes precedence over it.
+type upperString string
+
+func (u *upperString) UnmarshalText(text []byte) error {
+	*u = upperString(strings.ToUpper(string(text)))
+	return nil
+}
+
+func TestLoadParserPrecedesTextUnmarshaler(t *testing.T) {
+	type Config struct {
+		Value upperString `env:"VALUE"`
+	}
+
+	var c Config
+	err := LoadFrom(Map{"VALUE": "hello"}, &c,
+		WithParser(reflect.TypeOf(upperString("")), func(v string) (interface{}, error) {
+			return upperString("parsed:" + v), nil
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c.Value != "parsed:hello" {
+		t.Errorf("Value = %q, want %q (custom parser should win over UnmarshalText)", c.Value, "parsed:hello")
+	}
+}
+
+func TestLoadParserPointerMatching(t *testing.T) {
+	// Registering for the non-pointer type should still apply to a *T field...
+	type PtrConfig struct {
+		Site *url.URL `env:"SITE"`
+	}
+	var pc PtrConfig
+	err := LoadFrom(Map{"SITE": "https://example.com"}, &pc,
+		WithParser(reflect.TypeOf(url.URL{}), func(v string) (interface{}, error) {
+			return url.Parse(v)
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pc.Site == nil || pc.Site.Host != "example.com" {
+		t.Fatalf("Site = %+v, want host example.com", pc.Site)
+	}
+
+	// ...and registering for the pointer type should apply to a non-pointer field.
+	type ValueConfig struct {
+		Site url.URL `env:"SITE"`
+	}
+	var vc ValueConfig
+	err = LoadFrom(Map{"SITE": "https://example.org"}, &vc,
+		WithParser(reflect.TypeOf(&url.URL{}), func(v string) (interface{}, error) {
+			return url.Parse(v)
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vc.Site.Host != "example.org" {
+		t.Fatalf("Site = %+v, want host example.org", vc.Site)
+	}
+}
+
+func TestLoadParserInSlice(t *testing.T) {
+	type Config struct {
+		Sites []*url.URL `env:"SITES"`
+	}
+
+	var c Config
+	err := LoadFrom(Map{"SITES": "https://a.example https://b.example"}, &c,
+		WithParser(reflect.TypeOf(url.URL{}), func(v string) (interface{}, error) {
+			return url.Parse(v)
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(c.Sites) != 2 || c.Sites[0].Host != "a.example" || c.Sites[1].Host != "b.example" {
+		t.Fatalf("Sites = %+v, want [a.example b.example]", c.Sites)
+	}
+}
+
+func TestLoadParserPrecedesSliceKindDispatch(t *testing.T) {
+	type Tags []string
+
+	type Config struct {
+		Tags Tags `env:"TAGS"`
+	}
+
+	var c Config
+	err := LoadFrom(Map{"TAGS": "a,b,c"}, &c,
+		WithParser(reflect.TypeOf(Tags(nil)), func(v string) (interface{}, error) {
+			return Tags(strings.Split(v, ",")), nil
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := Tags{"a", "b", "c"}
+	if !reflect.DeepEqual(c.Tags, want) {
+		t.Errorf("Tags = %#v, want %#v (registered parser should win over slice-kind splitting on sliceSep)", c.Tags, want)
+	}
+}
+
+func TestLoadParserNilResult(t *testing.T) {
+	type Config struct {
+		Value string `env:"VALUE"`
+	}
+
+	var c Config
+	err := LoadFrom(Map{"VALUE": "x"}, &c,
+		WithParser(reflect.TypeOf(""), func(v string) (interface{}, error) {
+			return nil, nil
+		}),
+	)
+	if !errors.Is(err, ErrUnsupportedType) {
+		t.Fatalf("err = %v, want ErrUnsupportedType", err)
+	}
+}
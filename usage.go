@@ -0,0 +1,80 @@
+package env
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"text/tabwriter"
+	"text/template"
+)
+
+// UsageVar describes a single environment variable declared by a struct's
+// `env` tags, for use in Usage/UsageTemplate templates.
+type UsageVar struct {
+	Name       string // full variable name, including any global or nested prefix.
+	Type       string // Go type of the struct field, e.g. "int" or "[]string".
+	Required   bool
+	HasDefault bool
+	Default    string // the field's default value; meaningful only if HasDefault.
+	Doc        string // the field's envDoc tag, if any.
+}
+
+// defaultUsageTemplate renders UsageVars as a tab-separated table; see Usage,
+// which executes it against a tabwriter.Writer.
+const defaultUsageTemplate = `NAME	TYPE	REQUIRED	DEFAULT	DESCRIPTION
+{{range .}}{{.Name}}	{{.Type}}	{{.Required}}	{{.Default}}	{{.Doc}}
+{{end}}`
+
+// Usage writes a table describing every environment variable declared by
+// dst's `env` tags to w, in struct-declaration order. It walks dst using the
+// same tag rules as Load/LoadFrom, including opts, but never reads from a
+// Provider: it describes what Load would look for, not what is currently
+// set. dst must be a non-nil struct pointer, otherwise Usage returns
+// ErrInvalidArgument. See UsageTemplate to customize the output format.
+func Usage(dst interface{}, w io.Writer, opts ...Option) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	if err := UsageTemplate(dst, tw, defaultUsageTemplate, opts...); err != nil {
+		return err
+	}
+	return tw.Flush()
+}
+
+// UsageTemplate is like Usage, but renders the variables using tmpl, a
+// text/template executed with a []UsageVar, instead of the built-in table.
+// This allows projects to produce custom formats, e.g. Markdown.
+func UsageTemplate(dst interface{}, w io.Writer, tmpl string, opts ...Option) error {
+	rv := reflect.ValueOf(dst)
+	if !structPtr(rv) {
+		return ErrInvalidArgument
+	}
+
+	l := newLoader(OS, opts...)
+	vars, err := l.parseVars(rv.Elem(), l.prefix)
+	if err != nil {
+		return err
+	}
+
+	t, err := template.New("usage").Parse(tmpl)
+	if err != nil {
+		return fmt.Errorf("env: invalid usage template: %w", err)
+	}
+
+	return t.Execute(w, usageVars(vars))
+}
+
+// usageVars converts internal variables into the UsageVar shape exposed to
+// templates, preserving struct-declaration order.
+func usageVars(vars []variable) []UsageVar {
+	uv := make([]UsageVar, len(vars))
+	for i, v := range vars {
+		uv[i] = UsageVar{
+			Name:       v.name,
+			Type:       v.field.Type().String(),
+			Required:   v.required,
+			HasDefault: v.hasDefault,
+			Default:    v.defaultValue,
+			Doc:        v.doc,
+		}
+	}
+	return uv
+}
@@ -0,0 +1,77 @@
+package env
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestUsageOrderingAndPrefix(t *testing.T) {
+	type DB struct {
+		Host string `env:"HOST" envDefault:"localhost" envDoc:"database host"`
+		Port int    `env:"PORT" envDoc:"database port"`
+	}
+	type Config struct {
+		Name  string `env:"NAME" envDoc:"app name"`
+		DB    DB     `envPrefix:"DB_"`
+		Debug bool   `env:"DEBUG,required"`
+	}
+
+	var c Config
+	var buf bytes.Buffer
+	if err := Usage(&c, &buf, WithPrefix("APP_")); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	wantOrder := []string{"APP_NAME", "APP_DB_HOST", "APP_DB_PORT", "APP_DEBUG"}
+	lastIdx := -1
+	for _, name := range wantOrder {
+		idx := strings.Index(out, name)
+		if idx < 0 {
+			t.Fatalf("output missing %q:\n%s", name, out)
+		}
+		if idx <= lastIdx {
+			t.Fatalf("%q is out of struct-declaration order:\n%s", name, out)
+		}
+		lastIdx = idx
+	}
+
+	if !strings.Contains(out, "localhost") {
+		t.Errorf("output should mention DB.Host's default value:\n%s", out)
+	}
+	if !strings.Contains(out, "database host") {
+		t.Errorf("output should mention DB.Host's envDoc:\n%s", out)
+	}
+	if !strings.Contains(out, "true") {
+		t.Errorf("output should mark APP_DEBUG as required:\n%s", out)
+	}
+}
+
+func TestUsageTemplate(t *testing.T) {
+	type Config struct {
+		Name string `env:"NAME" envDoc:"app name"`
+	}
+
+	var c Config
+	var buf bytes.Buffer
+
+	tmpl := `{{range .}}- {{.Name}} ({{.Type}}): {{.Doc}}
+{{end}}`
+	if err := UsageTemplate(&c, &buf, tmpl); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "- NAME (string): app name\n"
+	if buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestUsageInvalidArgument(t *testing.T) {
+	var buf bytes.Buffer
+	err := Usage("not a struct pointer", &buf)
+	if err != ErrInvalidArgument {
+		t.Fatalf("err = %v, want ErrInvalidArgument", err)
+	}
+}
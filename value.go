@@ -0,0 +1,154 @@
+package env
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// unmarshalerIface is the reflect.Type of the encoding.TextUnmarshaler
+// interface, used to detect user-defined types that implement it.
+var unmarshalerIface = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// structPtr reports whether rv is a non-nil pointer to a struct.
+func structPtr(rv reflect.Value) bool {
+	return rv.Kind() == reflect.Ptr && !rv.IsNil() && rv.Elem().Kind() == reflect.Struct
+}
+
+// kindOf reports whether field is of the specified reflect.Kind.
+func kindOf(field reflect.Value, kind reflect.Kind) bool {
+	return field.Kind() == kind
+}
+
+// implements reports whether field, or a pointer to field, implements iface.
+func implements(field reflect.Value, iface reflect.Type) bool {
+	if field.Type().Implements(iface) {
+		return true
+	}
+	return field.CanAddr() && field.Addr().Type().Implements(iface)
+}
+
+// setValue parses value according to field's kind and assigns the result to
+// field. If field implements encoding.TextUnmarshaler (directly or through
+// its pointer), UnmarshalText is used instead of the scalar dispatch below.
+func setValue(field reflect.Value, value string) error {
+	if implements(field, unmarshalerIface) {
+		return unmarshalText(field, value)
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if field.Type() == reflect.TypeOf(time.Duration(0)) {
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return err
+			}
+			field.SetInt(int64(d))
+			return nil
+		}
+		i, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	default:
+		return fmt.Errorf("%w %s", ErrUnsupportedType, field.Type())
+	}
+
+	return nil
+}
+
+// unmarshalText assigns value to field using its encoding.TextUnmarshaler
+// implementation, allocating the underlying pointer if field is a nil
+// pointer.
+func unmarshalText(field reflect.Value, value string) error {
+	if field.Kind() == reflect.Ptr && field.IsNil() {
+		field.Set(reflect.New(field.Type().Elem()))
+	}
+
+	target := field
+	if !field.Type().Implements(unmarshalerIface) {
+		target = field.Addr()
+	}
+
+	return target.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value))
+}
+
+// valueSetter parses value and assigns it to field. setValue itself and
+// loader.setValue (which additionally consults custom parsers) both satisfy
+// this signature.
+type valueSetter func(field reflect.Value, value string) error
+
+// setSlice parses values and assigns them, element by element, to a newly
+// allocated slice of field's type, which then replaces field's value. Each
+// element is parsed with set, so callers can plug in loader.setValue to
+// honor custom parsers registered via WithParser.
+func setSlice(field reflect.Value, values []string, set valueSetter) error {
+	slice := reflect.MakeSlice(field.Type(), len(values), len(values))
+	for i, value := range values {
+		if err := set(slice.Index(i), value); err != nil {
+			return err
+		}
+	}
+	field.Set(slice)
+	return nil
+}
+
+// setMap parses entries as "key<sep>value" pairs and assigns them to a newly
+// allocated map of field's type, which then replaces field's value. Empty
+// entries are skipped; duplicate keys keep the last value, like the
+// environment itself. Keys and values are parsed with set, so callers can
+// plug in loader.setValue to honor custom parsers registered via WithParser.
+func setMap(field reflect.Value, entries []string, sep string, set valueSetter) error {
+	keyType, valueType := field.Type().Key(), field.Type().Elem()
+	m := reflect.MakeMapWithSize(field.Type(), len(entries))
+
+	for _, entry := range entries {
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.SplitN(entry, sep, 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("env: invalid map entry %q, want key%svalue", entry, sep)
+		}
+
+		key := reflect.New(keyType).Elem()
+		if err := set(key, parts[0]); err != nil {
+			return err
+		}
+
+		value := reflect.New(valueType).Elem()
+		if err := set(value, parts[1]); err != nil {
+			return err
+		}
+
+		m.SetMapIndex(key, value)
+	}
+
+	field.Set(m)
+	return nil
+}